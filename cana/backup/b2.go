@@ -0,0 +1,241 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/yoga/enc/cana/config"
+)
+
+const b2AuthorizeURL = "https://api.backblazeb2.com/b2api/v2/b2_authorize_account"
+
+// B2Destination uploads backups to a Backblaze B2 bucket via its native
+// API (B2 also speaks S3-compatible requests, but the native API gives
+// cheaper small-file listing for GFS pruning).
+type B2Destination struct {
+	conf config.B2Config
+	http *http.Client
+}
+
+func NewB2Destination(conf config.B2Config) (*B2Destination, error) {
+	if conf.Bucket == "" || conf.KeyID == "" || conf.ApplicationKey == "" {
+		return nil, fmt.Errorf("b2 backup destination requires bucket, key_id and application_key")
+	}
+
+	return &B2Destination{conf: conf, http: &http.Client{}}, nil
+}
+
+func (d *B2Destination) Name() string { return "b2:" + d.conf.Bucket }
+
+type b2Session struct {
+	apiURL      string
+	authToken   string
+	bucketID    string
+	downloadURL string
+}
+
+// authorize logs in and resolves conf.Bucket to its bucket ID; B2 accounts
+// are otherwise only addressable by that opaque ID, not the bucket name.
+func (d *B2Destination) authorize() (*b2Session, error) {
+	req, err := http.NewRequest("GET", b2AuthorizeURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(d.conf.KeyID, d.conf.ApplicationKey)
+
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		raw, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("b2 authorize: %s: %s", resp.Status, raw)
+	}
+
+	var auth struct {
+		AccountID   string `json:"accountId"`
+		ApiURL      string `json:"apiUrl"`
+		AuthToken   string `json:"authorizationToken"`
+		DownloadURL string `json:"downloadUrl"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil {
+		return nil, err
+	}
+
+	sess := &b2Session{apiURL: auth.ApiURL, authToken: auth.AuthToken, downloadURL: auth.DownloadURL}
+
+	var list struct {
+		Buckets []struct {
+			BucketID   string `json:"bucketId"`
+			BucketName string `json:"bucketName"`
+		} `json:"buckets"`
+	}
+	if err := sess.call("b2_list_buckets", map[string]string{"accountId": auth.AccountID, "bucketName": d.conf.Bucket}, &list); err != nil {
+		return nil, err
+	}
+	for _, b := range list.Buckets {
+		if b.BucketName == d.conf.Bucket {
+			sess.bucketID = b.BucketID
+		}
+	}
+	if sess.bucketID == "" {
+		return nil, fmt.Errorf("b2 bucket %q not found", d.conf.Bucket)
+	}
+
+	return sess, nil
+}
+
+func (s *b2Session) call(api string, body interface{}, out interface{}) error {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", s.apiURL+"/b2api/v2/"+api, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", s.authToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respRaw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("b2 %s: %s: %s", api, resp.Status, respRaw)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(respRaw, out)
+}
+
+func (d *B2Destination) Put(key string, r io.Reader) error {
+	sess, err := d.authorize()
+	if err != nil {
+		return err
+	}
+
+	var uploadURL struct {
+		UploadURL string `json:"uploadUrl"`
+		AuthToken string `json:"authorizationToken"`
+	}
+	if err := sess.call("b2_get_upload_url", map[string]string{"bucketId": sess.bucketID}, &uploadURL); err != nil {
+		return err
+	}
+
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	sum := sha1.Sum(raw)
+
+	req, err := http.NewRequest("POST", uploadURL.UploadURL, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", uploadURL.AuthToken)
+	req.Header.Set("X-Bz-File-Name", url.PathEscape(d.conf.Prefix+key))
+	req.Header.Set("Content-Type", "b2/x-auto")
+	req.Header.Set("X-Bz-Content-Sha1", hex.EncodeToString(sum[:]))
+
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		respRaw, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("b2 upload %s: %s: %s", key, resp.Status, respRaw)
+	}
+
+	return nil
+}
+
+func (d *B2Destination) List() ([]Object, error) {
+	sess, err := d.authorize()
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []Object
+	startFileName := ""
+
+	for {
+		body := map[string]interface{}{"bucketId": sess.bucketID, "prefix": d.conf.Prefix, "maxFileCount": 1000}
+		if startFileName != "" {
+			body["startFileName"] = startFileName
+		}
+
+		var list struct {
+			Files []struct {
+				FileName        string `json:"fileName"`
+				UploadTimestamp int64  `json:"uploadTimestamp"`
+			} `json:"files"`
+			NextFileName string `json:"nextFileName"`
+		}
+		if err := sess.call("b2_list_file_names", body, &list); err != nil {
+			return nil, err
+		}
+
+		for _, f := range list.Files {
+			objects = append(objects, Object{
+				Key:          f.FileName,
+				LastModified: time.UnixMilli(f.UploadTimestamp),
+			})
+		}
+
+		if list.NextFileName == "" {
+			break
+		}
+		startFileName = list.NextFileName
+	}
+
+	return objects, nil
+}
+
+func (d *B2Destination) Delete(key string) error {
+	sess, err := d.authorize()
+	if err != nil {
+		return err
+	}
+
+	var list struct {
+		Files []struct {
+			FileName string `json:"fileName"`
+			FileID   string `json:"fileId"`
+		} `json:"files"`
+	}
+	if err := sess.call("b2_list_file_names", map[string]interface{}{"bucketId": sess.bucketID, "prefix": key, "maxFileCount": 1}, &list); err != nil {
+		return err
+	}
+
+	for _, f := range list.Files {
+		if f.FileName != key {
+			continue
+		}
+		return sess.call("b2_delete_file_version", map[string]string{"fileName": f.FileName, "fileId": f.FileID}, nil)
+	}
+
+	return fmt.Errorf("b2 delete %s: not found", key)
+}
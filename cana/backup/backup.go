@@ -0,0 +1,149 @@
+// Package backup runs the configured backup script on a timer and exposes
+// a one-shot entry point for `enc backup run`. When Destinations are
+// configured, each run is also encrypted and shipped off-site, with the
+// GFS retention policy applied afterwards.
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/yoga/enc/cana/config"
+	"github.com/yoga/enc/cana/events"
+	"github.com/yoga/enc/cana/metrics"
+)
+
+var lastRunFailed int32
+
+// Start runs the backup described by conf every conf.Period seconds.
+func Start(conf config.BackupsConfig) {
+	go func() {
+		ticker := time.NewTicker(time.Duration(conf.Period) * time.Second)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			run(conf)
+		}
+	}()
+}
+
+// RunOnce runs the backup described by conf once, synchronously.
+func RunOnce(conf config.BackupsConfig) error {
+	return run(conf)
+}
+
+func run(conf config.BackupsConfig) error {
+	err := runAndShip(conf)
+	if err != nil {
+		atomic.StoreInt32(&lastRunFailed, 1)
+		metrics.BackupResults.WithLabelValues("failure").Inc()
+		events.Emit("backup", fmt.Sprintf("backup failed: %s", err))
+	} else {
+		atomic.StoreInt32(&lastRunFailed, 0)
+		metrics.BackupResults.WithLabelValues("success").Inc()
+		events.Emit("backup", "backup completed")
+	}
+	return err
+}
+
+func runAndShip(conf config.BackupsConfig) error {
+	if err := exec.Command(conf.Run, conf.Folder).Run(); err != nil {
+		return err
+	}
+
+	if len(conf.Destinations) == 0 {
+		return nil
+	}
+
+	exported, err := latestFile(conf.Folder)
+	if err != nil {
+		return err
+	}
+
+	return ship(conf, exported)
+}
+
+// latestFile returns the most recently written file in folder, the one the
+// backup script is assumed to have just produced.
+func latestFile(folder string) (string, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return "", err
+	}
+
+	var newest string
+	var newestMod time.Time
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			return "", err
+		}
+
+		if info.ModTime().After(newestMod) {
+			newest = e.Name()
+			newestMod = info.ModTime()
+		}
+	}
+
+	if newest == "" {
+		return "", fmt.Errorf("no backup file found in %s", folder)
+	}
+
+	return filepath.Join(folder, newest), nil
+}
+
+// ship encrypts exported and uploads it to every configured Destination,
+// pruning each one against conf.Retention afterwards. Plaintext never
+// leaves this function: Encrypt runs before Put ever sees the data.
+func ship(conf config.BackupsConfig, exported string) error {
+	f, err := os.Open(exported)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	encrypted, err := Encrypt(conf.Passphrase, f)
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := io.ReadAll(encrypted)
+	if err != nil {
+		return err
+	}
+
+	key := filepath.Base(exported) + ".enc"
+
+	for _, dc := range conf.Destinations {
+		dest, err := NewDestination(dc)
+		if err != nil {
+			return err
+		}
+
+		if err := dest.Put(key, bytes.NewReader(ciphertext)); err != nil {
+			return fmt.Errorf("%s: %w", dest.Name(), err)
+		}
+
+		if err := Prune(dest, conf.Retention); err != nil {
+			return fmt.Errorf("%s: prune: %w", dest.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// Healthy reports whether the most recent backup run succeeded, used by
+// GET /readyz. It returns true until a first run has completed.
+func Healthy() bool {
+	return atomic.LoadInt32(&lastRunFailed) == 0
+}
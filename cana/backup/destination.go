@@ -0,0 +1,45 @@
+package backup
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/yoga/enc/cana/config"
+)
+
+// Object describes a previously uploaded backup, as returned by a
+// Destination's List, for Prune to apply the GFS retention policy against.
+type Object struct {
+	Key          string
+	LastModified time.Time
+}
+
+// Destination is an off-site target a backup export can be streamed to.
+// Implementations never see plaintext: ship always wraps the export reader
+// with Encrypt before handing it to Put.
+type Destination interface {
+	Name() string
+	// Put uploads r under key. No retention metadata is attached to the
+	// object; Prune works only from the LastModified time List reports.
+	Put(key string, r io.Reader) error
+	// List returns every object previously uploaded by this destination,
+	// for Prune to decide what to keep.
+	List() ([]Object, error)
+	// Delete removes a previously uploaded object by key.
+	Delete(key string) error
+}
+
+// NewDestination builds the Destination described by conf.
+func NewDestination(conf config.DestinationConfig) (Destination, error) {
+	switch conf.Kind {
+	case "s3":
+		return NewS3Destination(conf.S3)
+	case "b2":
+		return NewB2Destination(conf.B2)
+	case "webdav":
+		return NewWebDAVDestination(conf.WebDAV)
+	default:
+		return nil, fmt.Errorf("unknown backup destination kind '%s'", conf.Kind)
+	}
+}
@@ -0,0 +1,71 @@
+package backup
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/yoga/enc/cana/config"
+)
+
+// Prune lists everything previously uploaded to d and deletes whatever
+// falls outside the GFS (grand-father-father-son) policy in retention: the
+// most recent Daily daily backups, Weekly weekly ones and Monthly monthly
+// ones are kept, one per calendar bucket, and everything else is removed.
+func Prune(d Destination, retention config.GFSRetention) error {
+	objs, err := d.List()
+	if err != nil {
+		return err
+	}
+
+	keep := gfsKeep(objs, retention)
+
+	for _, o := range objs {
+		if keep[o.Key] {
+			continue
+		}
+		if err := d.Delete(o.Key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func gfsKeep(objs []Object, retention config.GFSRetention) map[string]bool {
+	sorted := make([]Object, len(objs))
+	copy(sorted, objs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].LastModified.After(sorted[j].LastModified)
+	})
+
+	keep := make(map[string]bool)
+	seenDay := make(map[string]bool)
+	seenWeek := make(map[string]bool)
+	seenMonth := make(map[string]bool)
+	var daily, weekly, monthly int
+
+	for _, o := range sorted {
+		year, week := o.LastModified.ISOWeek()
+		dayKey := o.LastModified.Format("2006-01-02")
+		weekKey := fmt.Sprintf("%d-W%02d", year, week)
+		monthKey := o.LastModified.Format("2006-01")
+
+		if daily < retention.Daily && !seenDay[dayKey] {
+			keep[o.Key] = true
+			seenDay[dayKey] = true
+			daily++
+		}
+		if weekly < retention.Weekly && !seenWeek[weekKey] {
+			keep[o.Key] = true
+			seenWeek[weekKey] = true
+			weekly++
+		}
+		if monthly < retention.Monthly && !seenMonth[monthKey] {
+			keep[o.Key] = true
+			seenMonth[monthKey] = true
+			monthly++
+		}
+	}
+
+	return keep
+}
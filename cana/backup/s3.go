@@ -0,0 +1,144 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yoga/enc/cana/config"
+)
+
+// S3Destination uploads backups to an S3 bucket, signing requests with
+// AWS Signature Version 4 directly over net/http rather than a vendored
+// SDK. Credentials come from the standard AWS_ACCESS_KEY_ID and
+// AWS_SECRET_ACCESS_KEY environment variables.
+type S3Destination struct {
+	conf config.S3Config
+	http *http.Client
+}
+
+func NewS3Destination(conf config.S3Config) (*S3Destination, error) {
+	if conf.Region == "" || conf.Bucket == "" {
+		return nil, fmt.Errorf("s3 backup destination requires region and bucket")
+	}
+	if os.Getenv("AWS_ACCESS_KEY_ID") == "" || os.Getenv("AWS_SECRET_ACCESS_KEY") == "" {
+		return nil, fmt.Errorf("s3 backup destination requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+
+	return &S3Destination{conf: conf, http: &http.Client{}}, nil
+}
+
+func (d *S3Destination) Name() string { return "s3:" + d.conf.Bucket }
+
+func (d *S3Destination) Put(key string, r io.Reader) error {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PUT", d.objectURL(d.conf.Prefix+key), bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+
+	resp, err := d.do(req, s3sha256Hex(raw))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("s3 put %s: %s", key, resp.Status)
+	}
+
+	return nil
+}
+
+func (d *S3Destination) List() ([]Object, error) {
+	var objects []Object
+	continuationToken := ""
+
+	for {
+		url := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/?list-type=2&prefix=%s", d.conf.Bucket, d.conf.Region, d.conf.Prefix)
+		if continuationToken != "" {
+			url += "&continuation-token=" + continuationToken
+		}
+
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := d.do(req, s3sha256Hex(nil))
+		if err != nil {
+			return nil, err
+		}
+
+		raw, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("s3 list: %s: %s", resp.Status, raw)
+		}
+
+		var out struct {
+			Contents []struct {
+				Key          string    `xml:"Key"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err := xml.Unmarshal(raw, &out); err != nil {
+			return nil, err
+		}
+
+		for _, c := range out.Contents {
+			objects = append(objects, Object{Key: c.Key, LastModified: c.LastModified})
+		}
+
+		if !out.IsTruncated {
+			break
+		}
+		continuationToken = out.NextContinuationToken
+	}
+
+	return objects, nil
+}
+
+func (d *S3Destination) Delete(key string) error {
+	req, err := http.NewRequest("DELETE", d.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := d.do(req, s3sha256Hex(nil))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("s3 delete %s: %s", key, resp.Status)
+	}
+
+	return nil
+}
+
+func (d *S3Destination) objectURL(key string) string {
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", d.conf.Bucket, d.conf.Region, strings.TrimPrefix(key, "/"))
+}
+
+func (d *S3Destination) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	req.Host = fmt.Sprintf("%s.s3.%s.amazonaws.com", d.conf.Bucket, d.conf.Region)
+	s3Sign(req, payloadHash, d.conf.Region, os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"))
+	return d.http.Do(req)
+}
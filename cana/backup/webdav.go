@@ -0,0 +1,150 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yoga/enc/cana/config"
+)
+
+// WebDAVDestination uploads backups to a WebDAV share, e.g. a Nextcloud
+// instance.
+type WebDAVDestination struct {
+	conf config.WebDAVConfig
+	http *http.Client
+}
+
+func NewWebDAVDestination(conf config.WebDAVConfig) (*WebDAVDestination, error) {
+	if conf.URL == "" {
+		return nil, fmt.Errorf("webdav backup destination requires a url")
+	}
+
+	return &WebDAVDestination{conf: conf, http: &http.Client{}}, nil
+}
+
+func (d *WebDAVDestination) Name() string { return "webdav:" + d.conf.URL }
+
+func (d *WebDAVDestination) Put(key string, r io.Reader) error {
+	req, err := http.NewRequest("PUT", d.objectURL(key), r)
+	if err != nil {
+		return err
+	}
+	d.authenticate(req)
+
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webdav put %s: %s", key, resp.Status)
+	}
+
+	return nil
+}
+
+func (d *WebDAVDestination) List() ([]Object, error) {
+	req, err := http.NewRequest("PROPFIND", d.folderURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	d.authenticate(req)
+	req.Header.Set("Depth", "1")
+	req.Header.Set("Content-Type", "application/xml")
+	req.Body = io.NopCloser(bytes.NewReader([]byte(`<?xml version="1.0"?><d:propfind xmlns:d="DAV:"><d:prop><d:displayname/><d:getlastmodified/></d:prop></d:propfind>`)))
+
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("webdav propfind: %s: %s", resp.Status, raw)
+	}
+
+	var ms struct {
+		Responses []struct {
+			Href     string `xml:"href"`
+			Propstat []struct {
+				Prop struct {
+					DisplayName  string `xml:"displayname"`
+					LastModified string `xml:"getlastmodified"`
+				} `xml:"prop"`
+			} `xml:"propstat"`
+		} `xml:"response"`
+	}
+	if err := xml.Unmarshal(raw, &ms); err != nil {
+		return nil, err
+	}
+
+	var objects []Object
+	for _, r := range ms.Responses {
+		name := ""
+		lastMod := ""
+		for _, ps := range r.Propstat {
+			if ps.Prop.DisplayName != "" {
+				name = ps.Prop.DisplayName
+			}
+			if ps.Prop.LastModified != "" {
+				lastMod = ps.Prop.LastModified
+			}
+		}
+
+		// The folder itself is listed alongside its children; skip entries
+		// whose href is a collection (trailing slash).
+		if name == "" || strings.HasSuffix(r.Href, "/") {
+			continue
+		}
+
+		t, _ := time.Parse(time.RFC1123, lastMod)
+		objects = append(objects, Object{Key: name, LastModified: t})
+	}
+
+	return objects, nil
+}
+
+func (d *WebDAVDestination) Delete(key string) error {
+	req, err := http.NewRequest("DELETE", d.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	d.authenticate(req)
+
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("webdav delete %s: %s", key, resp.Status)
+	}
+
+	return nil
+}
+
+func (d *WebDAVDestination) authenticate(req *http.Request) {
+	if d.conf.Username != "" {
+		req.SetBasicAuth(d.conf.Username, d.conf.Password)
+	}
+}
+
+func (d *WebDAVDestination) folderURL() string {
+	return strings.TrimRight(d.conf.URL, "/") + "/" + strings.Trim(d.conf.Folder, "/")
+}
+
+func (d *WebDAVDestination) objectURL(key string) string {
+	return d.folderURL() + "/" + strings.TrimPrefix(key, "/")
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/yoga/enc/cana/backup"
+	"github.com/yoga/enc/cana/config"
+	"github.com/yoga/enc/cana/log"
+)
+
+var backupConfFile = confFile
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Manage the local backup task.",
+}
+
+var backupRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the configured backup script once and exit.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if err := config.Load(backupConfFile); err != nil {
+			return err
+		}
+
+		if err := backup.RunOnce(config.Conf.Backups); err != nil {
+			return err
+		}
+
+		log.Infof("Backup written to %s.", log.Bold(config.Conf.Backups.Folder))
+		return nil
+	},
+}
+
+func init() {
+	backupCmd.PersistentFlags().StringVar(&backupConfFile, "config", backupConfFile, "JSON configuration file.")
+	backupCmd.AddCommand(backupRunCmd)
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/yoga/enc/cana/log"
+)
+
+// apiClient talks to a running `enc server` instance over its HTTPS API
+// using the same bearer token a browser session would get from /auth. The
+// server's certificate is typically self-signed, so when --fingerprint is
+// set the client pins against it (see `enc tls fingerprint`) instead of
+// relying on chain validation; without it, verification is skipped and a
+// warning is printed since the connection is then open to MITM.
+type apiClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+var (
+	clientURL         = "https://127.0.0.1:8443"
+	clientToken       = ""
+	clientFingerprint = ""
+)
+
+func newAPIClient() *apiClient {
+	tlsConf := &tls.Config{InsecureSkipVerify: true}
+
+	if clientFingerprint != "" {
+		want := strings.ToLower(clientFingerprint)
+		tlsConf.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("server presented no certificate")
+			}
+
+			leaf, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+
+			sum := sha256.Sum256(leaf.Raw)
+			if got := hex.EncodeToString(sum[:]); got != want {
+				return fmt.Errorf("server certificate fingerprint %s does not match pinned %s", got, want)
+			}
+
+			return nil
+		}
+	} else {
+		log.Importantf("No --fingerprint pinned, TLS verification is disabled for this connection.")
+	}
+
+	return &apiClient{
+		baseURL: clientURL,
+		token:   clientToken,
+		http: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: tlsConf,
+			},
+		},
+	}
+}
+
+func (c *apiClient) do(method, path string, body interface{}) ([]byte, error) {
+	var reader *bytes.Reader
+
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, raw)
+	}
+
+	return raw, nil
+}
+
+// addClientFlags registers the --url/--token flags shared by every
+// subcommand that talks to a running server instance.
+func addClientFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&clientURL, "url", clientURL, "Base URL of the running enc server.")
+	flags.StringVar(&clientToken, "token", clientToken, "Bearer token returned by /auth.")
+	flags.StringVar(&clientFingerprint, "fingerprint", clientFingerprint, "Pin the server's TLS certificate to this SHA-256 fingerprint, as printed by `enc tls fingerprint`.")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yoga/enc/cana/config"
+	"github.com/yoga/enc/cana/db"
+	"github.com/yoga/enc/cana/log"
+	"github.com/yoga/enc/cana/utils"
+)
+
+var (
+	exportConfFile = confFile
+	exportOutput   = "enc.tar"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export every store and record to a TAR archive.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if err := config.Load(exportConfFile); err != nil {
+			return err
+		}
+
+		if _, err := db.Setup(); err != nil {
+			return err
+		}
+
+		started := time.Now()
+		if err := db.Export(exportOutput); err != nil {
+			return err
+		}
+
+		log.Infof("Archived %s of data in %s to %s.", utils.FormatBytes(db.Size), time.Since(started), log.Bold(exportOutput))
+		return nil
+	},
+}
+
+func init() {
+	flags := exportCmd.Flags()
+	flags.StringVar(&exportConfFile, "config", exportConfFile, "JSON configuration file.")
+	flags.StringVar(&exportOutput, "output", exportOutput, "Export file name.")
+}
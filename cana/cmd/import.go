@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yoga/enc/cana/config"
+	"github.com/yoga/enc/cana/db"
+	"github.com/yoga/enc/cana/log"
+	"github.com/yoga/enc/cana/utils"
+)
+
+var importConfFile = confFile
+
+var importCmd = &cobra.Command{
+	Use:   "import <file.tar>",
+	Short: "Import stores and records from a TAR archive produced by 'enc export'.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		if err := config.Load(importConfFile); err != nil {
+			return err
+		}
+
+		if _, err := db.Setup(); err != nil {
+			return err
+		}
+
+		started := time.Now()
+		if err := db.Import(args[0]); err != nil {
+			return err
+		}
+
+		log.Infof("Imported %s of data in %s.", utils.FormatBytes(db.Size), time.Since(started))
+		return nil
+	},
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importConfFile, "config", importConfFile, "JSON configuration file.")
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yoga/enc/cana/config"
+)
+
+var passwordCost = bcrypt.DefaultCost
+
+var passwordCmd = &cobra.Command{
+	Use:   "password <password>",
+	Short: "Hash a password for the 'password' field of the server config.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		fmt.Println(config.Conf.HashPassword(args[0], passwordCost))
+		return nil
+	},
+}
+
+func init() {
+	passwordCmd.Flags().IntVar(&passwordCost, "cost", passwordCost, "bcrypt cost factor.")
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+)
+
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Manage records within a store on a running enc server.",
+}
+
+var recordGetCmd = &cobra.Command{
+	Use:   "get <store-id> <record-id>",
+	Short: "Print a record's buffer to standard output.",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(_ *cobra.Command, args []string) error {
+		raw, err := newAPIClient().do("GET", fmt.Sprintf("/api/store/%s/record/%s/buffer", args[0], args[1]), nil)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(raw))
+		return nil
+	},
+}
+
+var recordPutFile string
+
+var recordPutCmd = &cobra.Command{
+	Use:   "put <store-id>",
+	Short: "Create a record in a store from a local file.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		raw, err := ioutil.ReadFile(recordPutFile)
+		if err != nil {
+			return err
+		}
+
+		resp, err := newAPIClient().do("POST", fmt.Sprintf("/api/store/%s/records", args[0]), map[string]string{
+			"name": recordPutFile,
+			"data": string(raw),
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(resp))
+		return nil
+	},
+}
+
+func init() {
+	addClientFlags(recordCmd.PersistentFlags())
+	recordPutCmd.Flags().StringVar(&recordPutFile, "file", "", "Local file to upload as the record's contents.")
+
+	recordCmd.AddCommand(recordGetCmd)
+	recordCmd.AddCommand(recordPutCmd)
+}
@@ -0,0 +1,40 @@
+// Package cmd implements the `enc` command tree: a long-running `server`
+// plus one-shot and client subcommands, all sharing config.Conf and the
+// log package. It replaces the flat flag.Parse()-driven main.go, so that
+// --help is per-subcommand and shell-completion works out of the box.
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/yoga/enc/cana/log"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "enc",
+	Short: "API Locker: an encrypted records server and its client tools.",
+}
+
+// Execute runs the selected subcommand, exiting the process on error.
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(serverCmd)
+	rootCmd.AddCommand(passwordCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(tlsCmd)
+	rootCmd.AddCommand(storeCmd)
+	rootCmd.AddCommand(recordCmd)
+}
+
+func fatalIf(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+
+	"github.com/yoga/enc/cana/app"
+	"github.com/yoga/enc/cana/backup"
+	"github.com/yoga/enc/cana/config"
+	"github.com/yoga/enc/cana/controllers"
+	"github.com/yoga/enc/cana/db"
+	"github.com/yoga/enc/cana/events"
+	"github.com/yoga/enc/cana/log"
+	"github.com/yoga/enc/cana/metrics"
+	"github.com/yoga/enc/cana/mfa"
+	"github.com/yoga/enc/cana/middlewares"
+	"github.com/yoga/enc/cana/scheduler"
+	"github.com/yoga/enc/cana/secrets"
+	"github.com/yoga/enc/cana/tls"
+	"github.com/yoga/enc/cana/updater"
+	"github.com/yoga/enc/cana/uploads"
+	"github.com/yoga/enc/cana/utils"
+)
+
+var (
+	signals        = make(chan os.Signal, 1)
+	appPath        = "/home/pi/projects/src/enc"
+	confFile       = "/home/pi/projects/src/sample_config.json"
+	debug          = false
+	logfile        = ""
+	logFormat      = "text"
+	noColors       = false
+	noAuth         = true
+	noUpdates      = false
+	tlsFingerprint = ""
+	router         = (*gin.Engine)(nil)
+)
+
+var serverCmd = &cobra.Command{
+	Use:   "server",
+	Short: "Run the HTTPS API and web application server.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		runServer()
+		return nil
+	},
+}
+
+func init() {
+	flags := serverCmd.Flags()
+	flags.StringVar(&appPath, "app", appPath, "Path of the web application to serve.")
+	flags.StringVar(&confFile, "config", confFile, "JSON configuration file.")
+	flags.BoolVar(&noAuth, "no-auth", noAuth, "Disable authentication.")
+	flags.BoolVar(&noUpdates, "no-updates", noUpdates, "Disable updates check.")
+
+	flags.BoolVar(&debug, "log-debug", debug, "Enable debug logs.")
+	flags.StringVar(&logfile, "log-file", logfile, "Log messages to this file instead of standard error.")
+	flags.StringVar(&logFormat, "log-format", logFormat, "Log output format, either 'text' or 'json'.")
+	flags.BoolVar(&noColors, "log-colors-off", noColors, "Disable colored output.")
+}
+
+func encSignalHandler() {
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	s := <-signals
+	log.Raw("\n")
+	log.Importantf("RECEIVED SIGNAL: %s", s)
+	db.Flush()
+	os.Exit(1)
+}
+
+// logFile is the handle opened for --log-file, if any, kept around so
+// runServer can close it on shutdown instead of leaking it or trying to
+// close log.Output (an io.Writer, which has no Close method).
+var logFile *os.File
+
+func setupLogging() {
+	log.WithColors = !noColors
+	log.Format = logFormat
+
+	if logfile != "" {
+		f, err := os.Create(logfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		logFile = f
+		log.Output = f
+	}
+
+	if debug == true {
+		log.MinLevel = log.DEBUG
+	} else {
+		log.MinLevel = log.INFO
+	}
+
+	log.Setup()
+}
+
+func setupDatabase() {
+	if _, err := db.Setup(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func setupSecrets() {
+	backend, err := secrets.New(config.Conf.Secrets)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	controllers.SecretsBackend = backend
+	log.Debugf("Secrets backend is %s.", log.Bold(config.Conf.Secrets.Backend))
+}
+
+func setupUploads() {
+	controllers.Uploads = uploads.NewManager(filepath.Join(appPath, "..", "uploads"))
+}
+
+func setupScheduler() {
+	if config.Conf.Scheduler.Enabled {
+		if err := events.Setup(); err != nil {
+			log.Fatal(err)
+		}
+
+		log.Debugf("Starting scheduler with a period of %ds ...", config.Conf.Scheduler.Period)
+		scheduler.Start(config.Conf.Scheduler.Period)
+	} else {
+		log.Importantf("Scheduler is disabled.")
+	}
+}
+
+func setupBackups() {
+	if config.Conf.Backups.Enabled {
+		log.Debugf("Starting backup task with a period of %ds ...", config.Conf.Backups.Period)
+		backup.Start(config.Conf.Backups)
+	} else {
+		log.Importantf("Backups are disabled.")
+	}
+}
+
+func setupUpdates() {
+	if noUpdates == false {
+		updater.Start(config.APP_VERSION)
+	}
+}
+
+func setupTLS() {
+	var err error
+
+	if config.Conf.Certificate, err = utils.ExpandPath(config.Conf.Certificate); err != nil {
+		log.Fatal(err)
+	} else if config.Conf.Key, err = utils.ExpandPath(config.Conf.Key); err != nil {
+		log.Fatal(err)
+	}
+
+	if utils.Exists(config.Conf.Certificate) == false || utils.Exists(config.Conf.Key) == false {
+		log.Importantf("TLS certificate files not found, generating new ones ...")
+		if err = tls.Generate(&config.Conf); err != nil {
+			log.Fatal(err)
+		}
+		log.Infof("New RSA key and certificate have been generated, remember to add them as exceptions to your browser!")
+	}
+
+	tlsFingerprint, err = tls.Fingerprint(config.Conf.Certificate)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Importantf("TLS certificate fingerprint is %s", log.Bold(tlsFingerprint))
+
+	if expiry, err := tls.Expiry(config.Conf.Certificate); err != nil {
+		log.Importantf("Failed to read certificate expiry for metrics: %s", err)
+	} else {
+		metrics.TLSCertExpiry.Set(float64(expiry.Unix()))
+	}
+}
+
+func setupRouter() *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+
+	router = gin.New()
+
+	err, webapp := app.Open(appPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	router.Use(middlewares.RequestLogger())
+	router.Use(middlewares.Security(tlsFingerprint))
+	router.Use(metrics.Middleware())
+	router.Use(middlewares.ServeStatic("/", webapp.Path, webapp.Manifest.Index))
+
+	router.GET("/healthz", controllers.GetHealthz)
+	router.GET("/readyz", controllers.GetReadyz)
+	router.GET("/metrics", metrics.Auth(), gin.WrapH(promhttp.Handler()))
+
+	api := router.Group("/api")
+	router.POST("/auth", controllers.Auth)
+
+	if noAuth == false {
+		api.Use(middlewares.AuthHandler())
+	} else {
+		log.Importantf("API authentication is disabled.")
+	}
+
+	controllers.App = webapp
+
+	if rp, err := mfa.NewWebAuthn(config.Conf.Address); err != nil {
+		log.Importantf("Failed to initialize WebAuthn, /api/mfa/webauthn routes will be unavailable: %s", err)
+	} else {
+		controllers.WebAuthnRP = rp
+	}
+
+	api.GET("/status", controllers.GetStatus)
+	api.GET("/manifest", controllers.GetManifest)
+	api.GET("/config", controllers.GetConfig)
+
+	api.GET("/events/clear", controllers.ClearEvents)
+
+	api.POST("/secrets/rotate", controllers.RotateSecrets)
+
+	api.POST("/mfa/totp/enroll", controllers.EnrollTOTP)
+	api.POST("/mfa/totp/verify", controllers.VerifyTOTP)
+	api.POST("/mfa/webauthn/register/begin", controllers.WebAuthnRegisterBegin)
+	api.POST("/mfa/webauthn/register/finish", controllers.WebAuthnRegisterFinish)
+	api.POST("/mfa/webauthn/login/begin", controllers.WebAuthnLoginBegin)
+	api.POST("/mfa/webauthn/login/finish", controllers.WebAuthnLoginFinish)
+
+	api.GET("/stores", controllers.ListStores)
+	api.POST("/stores", controllers.CreateStore)
+	api.GET("/store/:id", controllers.GetStore)
+	api.PUT("/store/:id", controllers.UpdateStore)
+	api.DELETE("/store/:id", controllers.DeleteStore)
+
+	api.GET("/store/:id/records", controllers.ListRecords)
+	api.POST("/store/:id/records", controllers.CreateRecord)
+	api.GET("/store/:id/record/:r_id", controllers.GetRecord)
+	api.GET("/store/:id/record/:r_id/buffer", controllers.GetRecordBuffer)
+	api.PUT("/store/:id/record/:r_id", controllers.UpdateRecord)
+	api.DELETE("/store/:id/record/:r_id", controllers.DeleteRecord)
+
+	api.POST("/store/:id/uploads", controllers.CreateUpload)
+	api.PUT("/store/:id/uploads/:uid/chunk/:n", controllers.PutChunk)
+	api.POST("/store/:id/uploads/:uid/complete", controllers.CompleteUpload)
+
+	return router
+}
+
+func runServer() {
+	go encSignalHandler()
+
+	setupLogging()
+	if logFile != nil {
+		defer logFile.Close()
+	}
+
+	log.Infof("%s (%s %s) is starting ...", log.Bold(config.APP_NAME+" v"+config.APP_VERSION), runtime.GOOS, runtime.GOARCH)
+	if confFile != "" {
+		if err := config.Load(confFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	setupDatabase()
+	setupSecrets()
+	setupUploads()
+	setupScheduler()
+	setupBackups()
+	setupUpdates()
+	setupTLS()
+	setupRouter()
+
+	address := fmt.Sprintf("%s:%d", config.Conf.Address, config.Conf.Port)
+	if address[0] == ':' {
+		address = "0.0.0.0" + address
+	}
+
+	log.Infof("Running on %s ...", log.Bold("https://"+address+"/"))
+	if err := router.RunTLS(address, config.Conf.Certificate, config.Conf.Key); err != nil {
+		log.Fatal(err)
+	}
+}
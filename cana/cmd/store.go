@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var storeCmd = &cobra.Command{
+	Use:   "store",
+	Short: "Manage stores on a running enc server.",
+}
+
+var storeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every store.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		raw, err := newAPIClient().do("GET", "/api/stores", nil)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(raw))
+		return nil
+	},
+}
+
+var storeCreateName string
+
+var storeCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a new store.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		raw, err := newAPIClient().do("POST", "/api/stores", map[string]string{"name": storeCreateName})
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(raw))
+		return nil
+	},
+}
+
+var storeDeleteCmd = &cobra.Command{
+	Use:   "delete <id>",
+	Short: "Delete a store.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		_, err := newAPIClient().do("DELETE", "/api/store/"+args[0], nil)
+		return err
+	},
+}
+
+func init() {
+	addClientFlags(storeCmd.PersistentFlags())
+	storeCreateCmd.Flags().StringVar(&storeCreateName, "name", "", "Name of the new store.")
+
+	storeCmd.AddCommand(storeListCmd)
+	storeCmd.AddCommand(storeCreateCmd)
+	storeCmd.AddCommand(storeDeleteCmd)
+}
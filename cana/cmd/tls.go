@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yoga/enc/cana/config"
+	tlspkg "github.com/yoga/enc/cana/tls"
+)
+
+var tlsConfFile = confFile
+
+var tlsCmd = &cobra.Command{
+	Use:   "tls",
+	Short: "Manage the server's TLS certificate.",
+}
+
+var tlsGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a new self-signed certificate at the configured paths.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if err := config.Load(tlsConfFile); err != nil {
+			return err
+		}
+		return tlspkg.Generate(&config.Conf)
+	},
+}
+
+var tlsFingerprintCmd = &cobra.Command{
+	Use:   "fingerprint",
+	Short: "Print the SHA-256 fingerprint of the configured certificate.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if err := config.Load(tlsConfFile); err != nil {
+			return err
+		}
+
+		fingerprint, err := tlspkg.Fingerprint(config.Conf.Certificate)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(fingerprint)
+		return nil
+	},
+}
+
+func init() {
+	tlsCmd.PersistentFlags().StringVar(&tlsConfFile, "config", tlsConfFile, "JSON configuration file.")
+	tlsCmd.AddCommand(tlsGenerateCmd)
+	tlsCmd.AddCommand(tlsFingerprintCmd)
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	APP_NAME    = "API Locker"
+	APP_VERSION = "1.0.0"
+)
+
+// SchedulerConfig controls the background task scheduler.
+type SchedulerConfig struct {
+	Enabled bool `json:"enabled"`
+	Period  int  `json:"period"`
+}
+
+// BackupsConfig controls the local backup task and, optionally, shipping
+// its output off-site.
+type BackupsConfig struct {
+	Enabled bool   `json:"enabled"`
+	Period  int    `json:"period"`
+	Folder  string `json:"folder"`
+	Run     string `json:"run"`
+
+	// Passphrase encrypts every export before it ever touches a
+	// Destination, so plaintext is never uploaded. The plaintext export
+	// that Run writes into Folder stays on local disk.
+	Passphrase string `json:"passphrase"`
+	// Retention is the grand-father-father-son policy applied to each
+	// Destination after a successful run.
+	Retention GFSRetention `json:"retention"`
+	// Destinations are the off-site targets each backup is shipped to, in
+	// addition to Folder.
+	Destinations []DestinationConfig `json:"destinations"`
+}
+
+// GFSRetention is a grand-father-father-son pruning policy: keep the last
+// Daily daily backups, Weekly weekly ones and Monthly monthly ones.
+type GFSRetention struct {
+	Daily   int `json:"daily"`
+	Weekly  int `json:"weekly"`
+	Monthly int `json:"monthly"`
+}
+
+// DestinationConfig selects and configures one off-site backup target.
+type DestinationConfig struct {
+	// Kind is one of "s3", "b2" or "webdav".
+	Kind   string       `json:"kind"`
+	S3     S3Config     `json:"s3"`
+	B2     B2Config     `json:"b2"`
+	WebDAV WebDAVConfig `json:"webdav"`
+}
+
+type S3Config struct {
+	Region string `json:"region"`
+	Bucket string `json:"bucket"`
+	Prefix string `json:"prefix"`
+}
+
+type B2Config struct {
+	Bucket         string `json:"bucket"`
+	KeyID          string `json:"key_id"`
+	ApplicationKey string `json:"application_key"`
+	Prefix         string `json:"prefix"`
+}
+
+type WebDAVConfig struct {
+	URL      string `json:"url"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Folder   string `json:"folder"`
+}
+
+// SecretsConfig selects and configures the backend used to wrap per-record
+// data encryption keys (see the secrets package).
+type SecretsConfig struct {
+	// Backend is one of "local", "vault", "aws-kms" or "gcp-kms".
+	Backend string         `json:"backend"`
+	Local   LocalKEKConfig `json:"local"`
+	Vault   VaultKEKConfig `json:"vault"`
+	AWSKMS  AWSKMSConfig   `json:"aws_kms"`
+	GCPKMS  GCPKMSConfig   `json:"gcp_kms"`
+}
+
+type LocalKEKConfig struct {
+	KeyFile string `json:"key_file"`
+}
+
+type VaultKEKConfig struct {
+	Address    string `json:"address"`
+	Token      string `json:"token"`
+	TransitKey string `json:"transit_key"`
+}
+
+type AWSKMSConfig struct {
+	Region string `json:"region"`
+	KeyID  string `json:"key_id"`
+}
+
+type GCPKMSConfig struct {
+	KeyName string `json:"key_name"`
+}
+
+// Config is the root configuration loaded from the JSON file passed via
+// --config.
+type Config struct {
+	Address     string `json:"address"`
+	Port        int    `json:"port"`
+	Certificate string `json:"certificate"`
+	Key         string `json:"key"`
+	// Password is the bcrypt hash printed by `enc password`, checked by
+	// controllers.Auth against the password given to /auth.
+	Password string `json:"password"`
+
+	Scheduler SchedulerConfig `json:"scheduler"`
+	Backups   BackupsConfig   `json:"backups"`
+	Secrets   SecretsConfig   `json:"secrets"`
+	Auth      AuthConfig      `json:"auth"`
+	Metrics   MetricsConfig   `json:"metrics"`
+}
+
+// MetricsConfig guards the /metrics endpoint with its own bearer token so
+// it can be scraped without going through the full API auth flow.
+type MetricsConfig struct {
+	Token string `json:"token"`
+}
+
+// AuthConfig controls JWT issuance and second-factor enforcement.
+type AuthConfig struct {
+	// JWTSecret signs the tokens issued by controllers.Auth.
+	JWTSecret string `json:"jwt_secret"`
+	// RequireMFA forces every authenticated user to have passed a second
+	// factor before AuthHandler accepts their token on any route.
+	RequireMFA bool `json:"require_mfa"`
+	// RequireMFAStores restricts MFA enforcement to these store IDs,
+	// instead of RequireMFA's global scope.
+	RequireMFAStores []string `json:"require_mfa_stores"`
+	// RequireMFARoutes lists route names (as passed to middlewares.Sensitive)
+	// that always require MFA regardless of RequireMFA, e.g. "record.buffer",
+	// "store.delete", "export".
+	RequireMFARoutes []string `json:"require_mfa_routes"`
+}
+
+// Conf is the globally loaded configuration, populated by Load.
+var Conf = Config{
+	Address: "0.0.0.0",
+	Port:    8443,
+	Secrets: SecretsConfig{
+		Backend: "local",
+	},
+}
+
+// Load reads and parses the JSON configuration file at path into Conf.
+func Load(path string) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, &Conf)
+}
+
+// HashPassword returns the bcrypt hash of password using the given cost.
+func (c Config) HashPassword(password string, cost int) string {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return ""
+	}
+	return string(hashed)
+}
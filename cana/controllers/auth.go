@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yoga/enc/cana/config"
+)
+
+type authRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type authClaims struct {
+	jwt.RegisteredClaims
+	AMR []string `json:"amr"`
+}
+
+// Auth checks the operator password and, on success, issues a JWT carrying
+// an "amr" (authentication methods reference) claim. The token starts out
+// with only "pwd" in it; VerifyTOTP and WebAuthnLoginFinish call
+// issueStepUpToken to reissue it with "otp" or "webauthn" added once a
+// second factor is confirmed, which is what satisfies middlewares.Sensitive
+// and config.Conf.Auth.RequireMFA.
+func Auth(ctx *gin.Context) {
+	var req authRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(config.Conf.Password), []byte(req.Password)) != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid password"})
+		return
+	}
+
+	token, err := issueToken([]string{"pwd"})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+func issueToken(amr []string) (string, error) {
+	claims := authClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		AMR: amr,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(config.Conf.Auth.JWTSecret))
+}
+
+// issueStepUpToken reissues the caller's token (as validated by
+// AuthHandler) with factor added to its amr claim, used once a second
+// factor has just been confirmed. The original factors are preserved so a
+// token that has completed both "otp" and "webauthn" over separate calls
+// still carries both.
+func issueStepUpToken(ctx *gin.Context, factor string) (string, error) {
+	amr := append(append([]string{}, ctx.GetStringSlice("amr")...), factor)
+	return issueToken(dedupeAMR(amr))
+}
+
+func dedupeAMR(amr []string) []string {
+	seen := make(map[string]bool, len(amr))
+	out := make([]string, 0, len(amr))
+
+	for _, m := range amr {
+		if !seen[m] {
+			seen[m] = true
+			out = append(out, m)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/yoga/enc/cana/config"
+)
+
+func TestDedupeAMR(t *testing.T) {
+	got := dedupeAMR([]string{"pwd", "otp", "otp", "webauthn", "pwd"})
+	want := []string{"pwd", "otp", "webauthn"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("dedupeAMR(...) = %v, want %v", got, want)
+	}
+}
+
+// TestIssueStepUpTokenUpgradesAMR guards against the class of bug where a
+// step-up reissue drops the original "pwd" factor instead of adding to it,
+// which would make RequireMFA checks regress after the first factor.
+func TestIssueStepUpTokenUpgradesAMR(t *testing.T) {
+	config.Conf.Auth.JWTSecret = "test-secret"
+
+	gin.SetMode(gin.TestMode)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	ctx.Set("amr", []string{"pwd"})
+
+	signed, err := issueStepUpToken(ctx, "otp")
+	if err != nil {
+		t.Fatalf("issueStepUpToken: %v", err)
+	}
+
+	token, err := jwt.ParseWithClaims(signed, &authClaims{}, func(t *jwt.Token) (interface{}, error) {
+		return []byte(config.Conf.Auth.JWTSecret), nil
+	})
+	if err != nil {
+		t.Fatalf("parsing reissued token: %v", err)
+	}
+
+	claims := token.Claims.(*authClaims)
+	want := []string{"pwd", "otp"}
+	if !reflect.DeepEqual(claims.AMR, want) {
+		t.Fatalf("reissued token amr = %v, want %v", claims.AMR, want)
+	}
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yoga/enc/cana/backup"
+	"github.com/yoga/enc/cana/config"
+	"github.com/yoga/enc/cana/db"
+	"github.com/yoga/enc/cana/scheduler"
+)
+
+// GetHealthz reports that the process is alive, for a liveness probe.
+func GetHealthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GetReadyz reports whether the database is open and, when enabled, the
+// scheduler and backup task are healthy, for a readiness probe.
+func GetReadyz(ctx *gin.Context) {
+	checks := gin.H{"db": db.Open()}
+
+	ready := db.Open()
+
+	if config.Conf.Scheduler.Enabled {
+		checks["scheduler"] = scheduler.Running()
+		ready = ready && scheduler.Running()
+	}
+
+	if config.Conf.Backups.Enabled {
+		checks["backup"] = backup.Healthy()
+		ready = ready && backup.Healthy()
+	}
+
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	ctx.JSON(status, gin.H{"ready": ready, "checks": checks})
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/webauthn"
+
+	"github.com/yoga/enc/cana/db"
+	"github.com/yoga/enc/cana/mfa"
+)
+
+var errNoTOTPSeed = errors.New("no TOTP seed enrolled")
+
+// WebAuthnRP is the relying party instance used by the WebAuthn{Register,Login}
+// handlers, configured from the Host the server is reachable on.
+var WebAuthnRP *webauthn.WebAuthn
+
+// adminUser identifies the single operator account this locker has; there
+// is no multi-user model, so it doubles as the TOTP account name and the
+// WebAuthn user handle.
+const adminUser = "admin"
+
+type totpVerifyRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// EnrollTOTP starts TOTP enrollment for the authenticated user: it
+// generates a new shared secret, wraps it with the configured secrets
+// backend for storage, and returns the otpauth:// URL to render as a QR
+// code. The secret only becomes active once confirmed via VerifyTOTP,
+// which confirms it only while no other seed has been confirmed yet.
+func EnrollTOTP(ctx *gin.Context) {
+	key, err := mfa.EnrollTOTP(adminUser)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	wrapped, err := SecretsBackend.Seal([]byte(key.Secret()))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	db.SetPendingTOTPSeed(wrapped)
+
+	ctx.JSON(http.StatusOK, gin.H{"url": key.URL(), "secret": key.Secret()})
+}
+
+// VerifyTOTP checks a 6-digit code against the user's TOTP secret,
+// reissuing the caller's token with "otp" added to its amr on success.
+// While no seed has been confirmed yet, the first successful call after
+// EnrollTOTP additionally confirms the pending secret, activating it for
+// every login after that. Once a seed is confirmed, codes are checked
+// against it only and a later pending seed is never confirmed.
+func VerifyTOTP(ctx *gin.Context) {
+	var req totpVerifyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	seed, enrolling, err := loadTOTPSeed()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !mfa.VerifyTOTP(seed, req.Code) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+		return
+	}
+
+	if enrolling {
+		if err := db.ConfirmTOTPSeed(); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	token, err := issueStepUpToken(ctx, "otp")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"enrolled": enrolling, "token": token})
+}
+
+// loadTOTPSeed returns the seed to verify a code against: the confirmed
+// seed once enrollment has completed, or the pending seed set by
+// EnrollTOTP while it hasn't. enrolling reports which one was used, so
+// VerifyTOTP knows whether this call should confirm it.
+func loadTOTPSeed() (seed string, enrolling bool, err error) {
+	wrapped, ok := db.GetTOTPSeed()
+	if !ok {
+		wrapped, ok = db.GetPendingTOTPSeed()
+		if !ok {
+			return "", false, errNoTOTPSeed
+		}
+		enrolling = true
+	}
+
+	plaintext, err := SecretsBackend.Unseal(wrapped)
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(plaintext), enrolling, nil
+}
+
+func currentWebAuthnUser() *mfa.WebAuthnUser {
+	return &mfa.WebAuthnUser{
+		ID:          []byte(adminUser),
+		Name:        adminUser,
+		DisplayName: adminUser,
+		Credentials: db.GetWebAuthnCredentials(),
+	}
+}
+
+// WebAuthnRegisterBegin starts FIDO2 credential registration for the
+// authenticated user.
+func WebAuthnRegisterBegin(ctx *gin.Context) {
+	if WebAuthnRP == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "webauthn is not configured"})
+		return
+	}
+
+	options, session, err := WebAuthnRP.BeginRegistration(currentWebAuthnUser())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	db.SetWebAuthnSession(session)
+	ctx.JSON(http.StatusOK, options)
+}
+
+// WebAuthnRegisterFinish completes FIDO2 credential registration.
+func WebAuthnRegisterFinish(ctx *gin.Context) {
+	if WebAuthnRP == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "webauthn is not configured"})
+		return
+	}
+
+	session, ok := db.GetWebAuthnSession()
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "no pending registration"})
+		return
+	}
+
+	cred, err := WebAuthnRP.FinishRegistration(currentWebAuthnUser(), *session, ctx.Request)
+	db.ClearWebAuthnSession()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db.AddWebAuthnCredential(*cred)
+	ctx.JSON(http.StatusOK, gin.H{"registered": true})
+}
+
+// WebAuthnLoginBegin starts a FIDO2 assertion ceremony.
+func WebAuthnLoginBegin(ctx *gin.Context) {
+	if WebAuthnRP == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "webauthn is not configured"})
+		return
+	}
+
+	options, session, err := WebAuthnRP.BeginLogin(currentWebAuthnUser())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db.SetWebAuthnSession(session)
+	ctx.JSON(http.StatusOK, options)
+}
+
+// WebAuthnLoginFinish completes a FIDO2 assertion ceremony and, on
+// success, reissues the caller's token with "webauthn" added to its amr.
+func WebAuthnLoginFinish(ctx *gin.Context) {
+	if WebAuthnRP == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "webauthn is not configured"})
+		return
+	}
+
+	session, ok := db.GetWebAuthnSession()
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "no pending login"})
+		return
+	}
+
+	_, err := WebAuthnRP.FinishLogin(currentWebAuthnUser(), *session, ctx.Request)
+	db.ClearWebAuthnSession()
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := issueStepUpToken(ctx, "webauthn")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"token": token})
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yoga/enc/cana/db"
+	"github.com/yoga/enc/cana/metrics"
+	"github.com/yoga/enc/cana/middlewares"
+	"github.com/yoga/enc/cana/uploads"
+)
+
+// GetRecordBuffer streams a record's decrypted contents. For records
+// created through the chunked upload API it honors an HTTP Range header by
+// decrypting only the chunks that overlap the requested byte range,
+// instead of pulling the whole record through memory.
+func GetRecordBuffer(ctx *gin.Context) {
+	storeID, recordID := ctx.Param("id"), ctx.Param("r_id")
+
+	if !middlewares.Sensitive(ctx, "record.buffer", storeID) {
+		return
+	}
+
+	metrics.RecordOps.WithLabelValues(storeID, "read").Inc()
+
+	wrapped, err := db.GetRecordDataKey(storeID, recordID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	dataKey, err := SecretsBackend.Unseal(wrapped)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	chunksDir, chunks, size, err := db.GetRecordChunks(storeID, recordID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	start, end, partial := parseRange(ctx.GetHeader("Range"), size)
+
+	ctx.Header("Accept-Ranges", "bytes")
+	if partial {
+		ctx.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+		ctx.Status(http.StatusPartialContent)
+	} else {
+		ctx.Status(http.StatusOK)
+	}
+
+	u := &uploads.Upload{Dir: chunksDir, DataKey: dataKey, Chunks: chunkMap(chunks)}
+
+	for _, c := range chunks {
+		chunkEnd := c.Offset + c.Size - 1
+		if chunkEnd < start || c.Offset > end {
+			continue
+		}
+
+		plaintext, err := u.DecryptChunk(c.Index)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		lo, hi := int64(0), int64(len(plaintext))
+		if c.Offset < start {
+			lo = start - c.Offset
+		}
+		if chunkEnd > end {
+			hi = end - c.Offset + 1
+		}
+
+		ctx.Writer.Write(plaintext[lo:hi])
+	}
+}
+
+func chunkMap(chunks []uploads.ChunkMeta) map[int]uploads.ChunkMeta {
+	m := make(map[int]uploads.ChunkMeta, len(chunks))
+	for _, c := range chunks {
+		m[c.Index] = c
+	}
+	return m
+}
+
+func parseRange(header string, size int64) (start, end int64, partial bool) {
+	end = size - 1
+	if header == "" || !strings.HasPrefix(header, "bytes=") {
+		return 0, end, false
+	}
+
+	spec := strings.TrimPrefix(header, "bytes=")
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 {
+		return 0, end, false
+	}
+
+	// A suffix range ("bytes=-500") has no start, meaning "the last 500
+	// bytes" rather than "from byte 0".
+	if parts[0] == "" {
+		n, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return 0, end, false
+		}
+
+		start = size - n
+		if start < 0 {
+			start = 0
+		}
+
+		return start, end, true
+	}
+
+	if v, err := strconv.ParseInt(parts[0], 10, 64); err == nil {
+		start = v
+	}
+
+	if parts[1] != "" {
+		if v, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+			end = v
+		}
+	}
+
+	if end >= size {
+		end = size - 1
+	}
+
+	return start, end, true
+}
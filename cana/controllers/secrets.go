@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yoga/enc/cana/config"
+	"github.com/yoga/enc/cana/db"
+	"github.com/yoga/enc/cana/log"
+	"github.com/yoga/enc/cana/secrets"
+)
+
+// SecretsBackend is the KEK backend selected by config.Conf.Secrets,
+// wired up in cmd/server.go alongside the database.
+var SecretsBackend secrets.Backend
+
+// rotateMu serializes RotateSecrets, as secrets.Backend requires of callers.
+var rotateMu sync.Mutex
+
+// RotateSecrets generates a new KEK from SecretsBackend and re-wraps every
+// record's data key under it, so the old KEK is no longer needed to read
+// any data. This is the only way to change the KEK in place; see
+// config.Conf.Secrets.Backend to switch backend entirely.
+func RotateSecrets(ctx *gin.Context) {
+	rotateMu.Lock()
+	defer rotateMu.Unlock()
+
+	if SecretsBackend == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "secrets backend not configured"})
+		return
+	}
+
+	oldBackend := SecretsBackend
+
+	newBackend, err := secrets.New(config.Conf.Secrets)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := newBackend.Rotate(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	rewrapped, err := db.RewrapDataKeys(func(wrapped []byte) ([]byte, error) {
+		return secrets.Rewrap(oldBackend, newBackend, wrapped)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	SecretsBackend = newBackend
+
+	log.FromContext(ctx.Request.Context()).Info("rotated secrets backend KEK", "rewrapped", rewrapped)
+
+	ctx.JSON(http.StatusOK, gin.H{"rewrapped": rewrapped})
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/yoga/enc/cana/db"
+	"github.com/yoga/enc/cana/metrics"
+	"github.com/yoga/enc/cana/uploads"
+)
+
+// Uploads tracks every in-progress chunked upload across all stores,
+// wired up alongside the database in cmd/server.go.
+var Uploads *uploads.Manager
+
+// CreateUpload generates a fresh per-record data key for storeID and
+// starts tracking a new chunked upload under it, returning the upload ID
+// that subsequent chunk PUTs and the final complete POST must reference.
+func CreateUpload(ctx *gin.Context) {
+	storeID := ctx.Param("id")
+
+	plaintext, _, err := SecretsBackend.GenerateDataKey()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	uploadID := uuid.NewString()
+	if _, err := Uploads.Create(uploadID, storeID, plaintext); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"upload_id": uploadID, "chunk_size": uploads.ChunkSize})
+}
+
+// PutChunk accepts one fixed-size chunk of an in-progress upload, verifying
+// its SHA-256 against the X-Chunk-SHA256 header before encrypting and
+// storing it. Chunks may be retried in any order; re-sending the same
+// index simply overwrites it.
+func PutChunk(ctx *gin.Context) {
+	uploadID := ctx.Param("uid")
+	n, err := strconv.Atoi(ctx.Param("n"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chunk index"})
+		return
+	}
+
+	u, ok := Uploads.Get(uploadID)
+	if !ok || u.StoreID != ctx.Param("id") {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "unknown upload"})
+		return
+	}
+
+	expectedSHA := ctx.GetHeader("X-Chunk-SHA256")
+	if expectedSHA == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "missing X-Chunk-SHA256 header"})
+		return
+	}
+
+	plaintext, err := ioutil.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if got := sha256Hex(plaintext); got != expectedSHA {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "chunk hash mismatch"})
+		return
+	}
+
+	if err := u.PutChunk(n, plaintext); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"stored": len(plaintext)})
+}
+
+type completeUploadRequest struct {
+	Name       string `json:"name" binding:"required"`
+	ChunkCount int    `json:"chunk_count" binding:"required"`
+}
+
+// CompleteUpload verifies every chunk's hash, rejecting the request if any
+// is missing or corrupt, wraps the upload's data key with the secrets
+// backend, and finalizes the upload into a record.
+func CompleteUpload(ctx *gin.Context) {
+	storeID := ctx.Param("id")
+	uploadID := ctx.Param("uid")
+
+	var req completeUploadRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	u, ok := Uploads.Get(uploadID)
+	if !ok || u.StoreID != storeID {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "unknown upload"})
+		return
+	}
+
+	chunks, err := u.Verify(req.ChunkCount)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	wrapped, err := SecretsBackend.Seal(u.DataKey)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordID := uuid.NewString()
+	record, err := db.PutRecordFromChunks(recordID, storeID, req.Name, u.Dir, wrapped, chunks)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The upload is now owned by the record; stop tracking it as
+	// in-progress but keep its chunk directory on disk for GetRecordBuffer.
+	Uploads.Delete(uploadID)
+	metrics.RecordOps.WithLabelValues(storeID, "write").Inc()
+
+	ctx.JSON(http.StatusOK, gin.H{"id": record.ID, "size": record.Size})
+}
@@ -0,0 +1,11 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%x", sum)
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// This locker has a single operator account (config.Conf.Password), so its
+// MFA state below is kept as package-level storage rather than keyed by
+// user, mirroring records' in-memory map until a real persistence layer
+// lands.
+var (
+	mfaMu sync.Mutex
+
+	pendingTOTPSeed   []byte
+	confirmedTOTPSeed []byte
+
+	webauthnCredentials []webauthn.Credential
+	webauthnSession     *webauthn.SessionData
+)
+
+// SetPendingTOTPSeed stores the wrapped TOTP seed returned by
+// controllers.EnrollTOTP until VerifyTOTP confirms it.
+func SetPendingTOTPSeed(wrapped []byte) {
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+	pendingTOTPSeed = wrapped
+}
+
+// GetPendingTOTPSeed returns the wrapped seed set by SetPendingTOTPSeed, if
+// any enrollment is in progress.
+func GetPendingTOTPSeed() ([]byte, bool) {
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+	return pendingTOTPSeed, pendingTOTPSeed != nil
+}
+
+// ConfirmTOTPSeed promotes the pending seed to confirmed, called once
+// VerifyTOTP accepts a code against it.
+func ConfirmTOTPSeed() error {
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+
+	if pendingTOTPSeed == nil {
+		return errors.New("no pending TOTP enrollment")
+	}
+
+	confirmedTOTPSeed = pendingTOTPSeed
+	pendingTOTPSeed = nil
+	return nil
+}
+
+// GetTOTPSeed returns the confirmed (activated) wrapped TOTP seed, if any.
+func GetTOTPSeed() ([]byte, bool) {
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+	return confirmedTOTPSeed, confirmedTOTPSeed != nil
+}
+
+// GetWebAuthnCredentials returns every credential registered so far, for
+// mfa.WebAuthnUser.
+func GetWebAuthnCredentials() []webauthn.Credential {
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+	return append([]webauthn.Credential(nil), webauthnCredentials...)
+}
+
+// AddWebAuthnCredential stores a newly registered credential.
+func AddWebAuthnCredential(cred webauthn.Credential) {
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+	webauthnCredentials = append(webauthnCredentials, cred)
+}
+
+// SetWebAuthnSession stashes the session data returned by BeginRegistration
+// or BeginLogin until the matching Finish call completes the ceremony.
+func SetWebAuthnSession(session *webauthn.SessionData) {
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+	webauthnSession = session
+}
+
+// GetWebAuthnSession returns the session stashed by SetWebAuthnSession.
+func GetWebAuthnSession() (*webauthn.SessionData, bool) {
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+	return webauthnSession, webauthnSession != nil
+}
+
+// ClearWebAuthnSession drops the in-progress ceremony's session data, used
+// once a Finish call completes or fails.
+func ClearWebAuthnSession() {
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+	webauthnSession = nil
+}
@@ -0,0 +1,52 @@
+package db
+
+import "testing"
+
+// TestTOTPSeedLifecycle guards against the regression where VerifyTOTP only
+// ever consulted the pending seed: once ConfirmTOTPSeed runs, the seed must
+// still be retrievable via GetTOTPSeed for every later login, and the
+// pending slot must be cleared so it doesn't leak into a later enrollment.
+func TestTOTPSeedLifecycle(t *testing.T) {
+	defer func() {
+		pendingTOTPSeed = nil
+		confirmedTOTPSeed = nil
+	}()
+
+	if _, ok := GetPendingTOTPSeed(); ok {
+		t.Fatal("expected no pending seed before enrollment starts")
+	}
+	if _, ok := GetTOTPSeed(); ok {
+		t.Fatal("expected no confirmed seed before enrollment starts")
+	}
+
+	SetPendingTOTPSeed([]byte("seed-v1"))
+
+	seed, ok := GetPendingTOTPSeed()
+	if !ok || string(seed) != "seed-v1" {
+		t.Fatalf("expected pending seed %q, got %q (ok=%v)", "seed-v1", seed, ok)
+	}
+
+	if err := ConfirmTOTPSeed(); err != nil {
+		t.Fatalf("ConfirmTOTPSeed: %v", err)
+	}
+
+	if _, ok := GetPendingTOTPSeed(); ok {
+		t.Fatal("expected pending seed to be cleared once confirmed")
+	}
+
+	confirmed, ok := GetTOTPSeed()
+	if !ok || string(confirmed) != "seed-v1" {
+		t.Fatalf("expected confirmed seed %q to survive past enrollment, got %q (ok=%v)", "seed-v1", confirmed, ok)
+	}
+}
+
+func TestConfirmTOTPSeedWithoutPendingFails(t *testing.T) {
+	defer func() {
+		pendingTOTPSeed = nil
+		confirmedTOTPSeed = nil
+	}()
+
+	if err := ConfirmTOTPSeed(); err == nil {
+		t.Fatal("expected an error confirming with no pending enrollment")
+	}
+}
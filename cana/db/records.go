@@ -0,0 +1,81 @@
+package db
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/yoga/enc/cana/uploads"
+)
+
+// Record is a single stored, encrypted blob within a store.
+type Record struct {
+	ID             string `json:"id"`
+	StoreID        string `json:"store_id"`
+	Name           string `json:"name"`
+	WrappedDataKey []byte `json:"wrapped_data_key"`
+	// ChunksDir is where the upload's encrypted chunk files live on disk,
+	// empty for records not created through the chunked upload API.
+	ChunksDir string              `json:"chunks_dir"`
+	Chunks    []uploads.ChunkMeta `json:"chunks"`
+	Size      int64               `json:"size"`
+}
+
+var (
+	recordsMu sync.Mutex
+	records   = map[string]*Record{}
+)
+
+// GetRecordDataKey returns the wrapped data key stored for record r_id in
+// store id, for the caller to secrets.Backend.Unseal.
+func GetRecordDataKey(storeID, recordID string) ([]byte, error) {
+	recordsMu.Lock()
+	defer recordsMu.Unlock()
+
+	r, ok := records[recordID]
+	if !ok || r.StoreID != storeID {
+		return nil, fmt.Errorf("record %s not found in store %s", recordID, storeID)
+	}
+
+	return r.WrappedDataKey, nil
+}
+
+// GetRecordChunks returns the on-disk chunk directory and layout of a
+// previously completed chunked-upload record, for GetRecordBuffer to serve
+// Range requests.
+func GetRecordChunks(storeID, recordID string) (string, []uploads.ChunkMeta, int64, error) {
+	recordsMu.Lock()
+	defer recordsMu.Unlock()
+
+	r, ok := records[recordID]
+	if !ok || r.StoreID != storeID {
+		return "", nil, 0, fmt.Errorf("record %s not found in store %s", recordID, storeID)
+	}
+
+	return r.ChunksDir, r.Chunks, r.Size, nil
+}
+
+// PutRecordFromChunks finalizes a chunked upload into a new record, storing
+// the wrapped data key, the on-disk chunk directory and the verified chunk
+// layout.
+func PutRecordFromChunks(id, storeID, name, chunksDir string, wrappedDataKey []byte, chunks []uploads.ChunkMeta) (*Record, error) {
+	var size int64
+	for _, c := range chunks {
+		size += c.Size
+	}
+
+	r := &Record{
+		ID:             id,
+		StoreID:        storeID,
+		Name:           name,
+		WrappedDataKey: wrappedDataKey,
+		ChunksDir:      chunksDir,
+		Chunks:         chunks,
+		Size:           size,
+	}
+
+	recordsMu.Lock()
+	records[id] = r
+	recordsMu.Unlock()
+
+	return r, nil
+}
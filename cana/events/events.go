@@ -0,0 +1,52 @@
+// Package events records notable occurrences (backup results, secret
+// rotations, ...) for the scheduler and UI event log to pick up.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is a single entry in the event log.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Message string    `json:"message"`
+}
+
+// logMu guards log, which is appended to from the scheduler's and the
+// backup task's independent ticker goroutines and read/cleared from HTTP
+// handlers.
+var (
+	logMu sync.Mutex
+	log   []Event
+)
+
+// Setup prepares the event log storage.
+func Setup() error {
+	logMu.Lock()
+	defer logMu.Unlock()
+	log = make([]Event, 0)
+	return nil
+}
+
+// Emit appends a new event to the log.
+func Emit(kind, message string) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	log = append(log, Event{Time: time.Now(), Kind: kind, Message: message})
+}
+
+// List returns every event recorded so far.
+func List() []Event {
+	logMu.Lock()
+	defer logMu.Unlock()
+	return append([]Event(nil), log...)
+}
+
+// Clear empties the event log, used by GET /api/events/clear.
+func Clear() {
+	logMu.Lock()
+	defer logMu.Unlock()
+	log = log[:0]
+}
@@ -0,0 +1,44 @@
+// Package metrics exposes the Prometheus collectors scraped from
+// GET /metrics, guarded by its own bearer token since it's meant to be
+// reachable by a scraper that doesn't otherwise have API access.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "enc",
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of API requests, labeled by route and status.",
+	}, []string{"route", "status"})
+
+	RecordOps = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "enc",
+		Name:      "record_operations_total",
+		Help:      "Record read/write operations, labeled by store and operation.",
+	}, []string{"store", "op"})
+
+	SchedulerTaskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "enc",
+		Name:      "scheduler_task_duration_seconds",
+		Help:      "Duration of scheduler tasks, labeled by task name.",
+	}, []string{"task"})
+
+	BackupResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "enc",
+		Name:      "backup_results_total",
+		Help:      "Backup task runs, labeled by result (success/failure).",
+	}, []string{"result"})
+
+	TLSCertExpiry = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "enc",
+		Name:      "tls_certificate_expiry_timestamp_seconds",
+		Help:      "Unix timestamp when the currently loaded TLS certificate expires.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(RequestDuration, RecordOps, SchedulerTaskDuration, BackupResults, TLSCertExpiry)
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yoga/enc/cana/config"
+)
+
+// Middleware records RequestDuration for every request, labeled by route
+// and status code.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		started := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		RequestDuration.WithLabelValues(route, strconv.Itoa(ctx.Writer.Status())).
+			Observe(time.Since(started).Seconds())
+	}
+}
+
+// Auth guards GET /metrics with its own bearer token, independent from the
+// API's JWT auth, so a Prometheus scraper doesn't need a full session.
+func Auth() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		expected := config.Conf.Metrics.Token
+		if expected == "" {
+			ctx.Next()
+			return
+		}
+
+		got := ctx.GetHeader("Authorization")
+		if got != fmt.Sprintf("Bearer %s", expected) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid metrics token"})
+			return
+		}
+
+		ctx.Next()
+	}
+}
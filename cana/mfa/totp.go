@@ -0,0 +1,42 @@
+// Package mfa implements the second-factor enrollment and verification
+// flows offered on top of the password check in controllers.Auth: TOTP
+// (RFC 6238) and WebAuthn/FIDO2.
+package mfa
+
+import (
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+
+	"github.com/yoga/enc/cana/config"
+)
+
+// TOTPSecret is a user's enrolled TOTP shared secret, encrypted at rest
+// with the configured secrets.Backend before being persisted by the db
+// package.
+type TOTPSecret struct {
+	User        string `json:"user"`
+	WrappedSeed []byte `json:"wrapped_seed"`
+}
+
+// EnrollTOTP generates a new TOTP key for user and returns its otpauth://
+// URL (to render as a QR code) along with the seed to wrap and store.
+func EnrollTOTP(user string) (*otp.Key, error) {
+	return totp.Generate(totp.GenerateOpts{
+		Issuer:      config.APP_NAME,
+		AccountName: user,
+	})
+}
+
+// VerifyTOTP checks a 6-digit code against seed using the current time
+// step, allowing for the usual +/-1 step clock skew.
+func VerifyTOTP(seed, code string) bool {
+	valid, _ := totp.ValidateCustom(code, seed, time.Now(), totp.ValidateOpts{
+		Period:    30,
+		Skew:      1,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+	return valid
+}
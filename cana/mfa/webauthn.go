@@ -0,0 +1,33 @@
+package mfa
+
+import (
+	"github.com/go-webauthn/webauthn/webauthn"
+
+	"github.com/yoga/enc/cana/config"
+)
+
+// WebAuthnUser adapts a locker user to the webauthn.User interface
+// expected by the go-webauthn/webauthn library.
+type WebAuthnUser struct {
+	ID          []byte
+	Name        string
+	DisplayName string
+	Credentials []webauthn.Credential
+}
+
+func (u *WebAuthnUser) WebAuthnID() []byte                         { return u.ID }
+func (u *WebAuthnUser) WebAuthnName() string                       { return u.Name }
+func (u *WebAuthnUser) WebAuthnDisplayName() string                { return u.DisplayName }
+func (u *WebAuthnUser) WebAuthnCredentials() []webauthn.Credential { return u.Credentials }
+func (u *WebAuthnUser) WebAuthnIcon() string                       { return "" }
+
+// NewWebAuthn builds the library instance used for both registration and
+// assertion ceremonies, identifying the relying party as this locker
+// instance.
+func NewWebAuthn(rpOrigin string) (*webauthn.WebAuthn, error) {
+	return webauthn.New(&webauthn.Config{
+		RPDisplayName: config.APP_NAME,
+		RPID:          rpOrigin,
+		RPOrigins:     []string{"https://" + rpOrigin},
+	})
+}
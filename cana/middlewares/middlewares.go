@@ -0,0 +1,152 @@
+// Package middlewares holds the Gin middleware chain shared by every route:
+// transport security headers, serving the bundled web app and enforcing
+// API authentication.
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/yoga/enc/cana/config"
+)
+
+// Security adds the standard hardening headers and pins the TLS
+// certificate fingerprint so the web app can warn if it changes
+// unexpectedly.
+func Security(tlsFingerprint string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("X-Content-Type-Options", "nosniff")
+		ctx.Header("X-Frame-Options", "DENY")
+		ctx.Header("X-TLS-Fingerprint", tlsFingerprint)
+		ctx.Next()
+	}
+}
+
+// ServeStatic serves the bundled web application at prefix, falling back
+// to index for any unmatched route so client-side routing works.
+func ServeStatic(prefix, path, index string) gin.HandlerFunc {
+	fs := http.Dir(path)
+	fileServer := http.StripPrefix(prefix, http.FileServer(fs))
+
+	return func(ctx *gin.Context) {
+		if strings.HasPrefix(ctx.Request.URL.Path, "/api") || ctx.Request.URL.Path == "/auth" {
+			ctx.Next()
+			return
+		}
+
+		if _, err := fs.Open(strings.TrimPrefix(ctx.Request.URL.Path, prefix)); err != nil {
+			ctx.Request.URL.Path = index
+		}
+
+		fileServer.ServeHTTP(ctx.Writer, ctx.Request)
+		ctx.Abort()
+	}
+}
+
+// claims is the JWT payload issued by controllers.Auth.
+type claims struct {
+	jwt.RegisteredClaims
+	// AMR lists the authentication methods the token's holder has
+	// completed, e.g. "pwd" or "pwd", "otp" / "pwd", "webauthn".
+	AMR []string `json:"amr"`
+}
+
+func hasAMR(c *claims, method string) bool {
+	for _, m := range c.AMR {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// sensitiveRoutes names routes that require a completed second factor when
+// config.Conf.Auth.RequireMFA (or the per-store variant) is set.
+var sensitiveRoutes = map[string]bool{
+	"record.buffer": true,
+	"store.delete":  true,
+	"export":        true,
+}
+
+// Sensitive marks the current route as requiring MFA, to be called at the
+// top of a handler before any side effect. storeID is the store the
+// request targets, used to evaluate config.Conf.Auth.RequireMFAStores; pass
+// "" for routes that aren't scoped to a single store.
+func Sensitive(ctx *gin.Context, name, storeID string) bool {
+	c, ok := ctx.Get("claims")
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
+		return false
+	}
+
+	tok := c.(*claims)
+	if requiresMFA(name, storeID) && !hasAMR(tok, "otp") && !hasAMR(tok, "webauthn") {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "this action requires a second authentication factor"})
+		return false
+	}
+
+	return true
+}
+
+func requiresMFA(name, storeID string) bool {
+	if config.Conf.Auth.RequireMFA {
+		return true
+	}
+
+	for _, r := range config.Conf.Auth.RequireMFARoutes {
+		if r == name {
+			return true
+		}
+	}
+
+	if !sensitiveRoutes[name] || len(config.Conf.Auth.RequireMFAStores) == 0 {
+		return false
+	}
+
+	for _, s := range config.Conf.Auth.RequireMFAStores {
+		if s == storeID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AuthHandler validates the bearer JWT issued by controllers.Auth and
+// stores its claims in the Gin context for Sensitive to inspect.
+func AuthHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		header := ctx.GetHeader("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		raw := strings.TrimPrefix(header, "Bearer ")
+
+		token, err := jwt.ParseWithClaims(raw, &claims{}, func(t *jwt.Token) (interface{}, error) {
+			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+			}
+			return []byte(config.Conf.Auth.JWTSecret), nil
+		})
+		if err != nil || !token.Valid {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		c := token.Claims.(*claims)
+		if config.Conf.Auth.RequireMFA && !hasAMR(c, "otp") && !hasAMR(c, "webauthn") {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "second factor required"})
+			return
+		}
+
+		ctx.Set("claims", c)
+		ctx.Set("amr", c.AMR)
+		ctx.Next()
+	}
+}
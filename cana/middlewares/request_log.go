@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/yoga/enc/cana/log"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// RequestLogger assigns a request ID (propagating X-Request-ID if the
+// caller already set one), injects a request-scoped *slog.Logger carrying
+// it into the request context, and logs one line per request with its
+// route, status and latency. Controllers pull the logger back out with
+// log.FromContext(ctx.Request.Context()).
+func RequestLogger() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(requestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		ctx.Header(requestIDHeader, requestID)
+
+		scoped := log.FromContext(ctx.Request.Context()).With(
+			"request_id", requestID,
+			"remote_ip", ctx.ClientIP(),
+		)
+		ctx.Request = ctx.Request.WithContext(log.WithContext(ctx.Request.Context(), scoped))
+
+		started := time.Now()
+		ctx.Next()
+
+		scoped.Info("request",
+			"method", ctx.Request.Method,
+			"path", ctx.FullPath(),
+			"status", ctx.Writer.Status(),
+			"latency_ms", time.Since(started).Milliseconds(),
+		)
+	}
+}
@@ -0,0 +1,65 @@
+// Package scheduler periodically runs the fixed set of tasks listed in
+// runTasks, reporting failures through the events package.
+package scheduler
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/yoga/enc/cana/config"
+	"github.com/yoga/enc/cana/events"
+	"github.com/yoga/enc/cana/metrics"
+	"github.com/yoga/enc/cana/tls"
+)
+
+var running int32
+
+// Start runs the scheduler loop every period seconds until the process
+// exits.
+func Start(period int) {
+	atomic.StoreInt32(&running, 1)
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(period) * time.Second)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			runTasks()
+		}
+	}()
+}
+
+// runTasks runs every periodic task the scheduler is responsible for,
+// timing each one so it shows up in metrics.SchedulerTaskDuration. Today
+// that's just refreshing the TLS certificate expiry gauge, which
+// setupTLS otherwise only sets once at startup.
+func runTasks() {
+	runTask("tls_cert_expiry_refresh", refreshTLSCertExpiry)
+}
+
+func runTask(name string, task func() error) {
+	start := time.Now()
+
+	if err := task(); err != nil {
+		events.Emit("scheduler", fmt.Sprintf("%s failed: %s", name, err))
+	}
+
+	metrics.SchedulerTaskDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+}
+
+func refreshTLSCertExpiry() error {
+	expiry, err := tls.Expiry(config.Conf.Certificate)
+	if err != nil {
+		return err
+	}
+
+	metrics.TLSCertExpiry.Set(float64(expiry.Unix()))
+	return nil
+}
+
+// Running reports whether the scheduler loop has been started, used by
+// GET /readyz.
+func Running() bool {
+	return atomic.LoadInt32(&running) == 1
+}
@@ -0,0 +1,51 @@
+package secrets
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigv4SignIsDeterministic(t *testing.T) {
+	sigv4Now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	defer func() { sigv4Now = time.Now }()
+
+	newReq := func() *http.Request {
+		req, err := http.NewRequest("POST", "https://kms.us-east-1.amazonaws.com/", strings.NewReader(`{"KeyId":"k"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Host = "kms.us-east-1.amazonaws.com"
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		req.Header.Set("X-Amz-Target", "AWSKeyManagementService.GenerateDataKey")
+		return req
+	}
+
+	body := []byte(`{"KeyId":"k"}`)
+
+	req1 := newReq()
+	sigv4Sign(req1, body, "us-east-1", "kms", "AKIDEXAMPLE", "secret", "")
+
+	req2 := newReq()
+	sigv4Sign(req2, body, "us-east-1", "kms", "AKIDEXAMPLE", "secret", "")
+
+	sig1 := req1.Header.Get("Authorization")
+	sig2 := req2.Header.Get("Authorization")
+
+	if sig1 == "" {
+		t.Fatal("expected a non-empty Authorization header")
+	}
+	if sig1 != sig2 {
+		t.Fatalf("signing the same request twice at the same time produced different signatures:\n%s\n%s", sig1, sig2)
+	}
+	if !strings.Contains(sig1, "Credential=AKIDEXAMPLE/20240102/us-east-1/kms/aws4_request") {
+		t.Fatalf("Authorization header missing expected credential scope: %s", sig1)
+	}
+
+	req3 := newReq()
+	sigv4Sign(req3, body, "us-east-1", "kms", "AKIDEXAMPLE", "different-secret", "")
+	if req3.Header.Get("Authorization") == sig1 {
+		t.Fatal("expected a different secret key to produce a different signature")
+	}
+}
@@ -0,0 +1,109 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io/ioutil"
+
+	"github.com/yoga/enc/cana/config"
+	"github.com/yoga/enc/cana/utils"
+)
+
+// LocalBackend is the original behavior: data keys are wrapped with
+// AES-GCM using a KEK stored in a local file (32 random bytes, generated
+// on first use and again on every Rotate).
+type LocalBackend struct {
+	conf config.LocalKEKConfig
+	kek  []byte
+}
+
+// NewLocalBackend loads (or creates) the local KEK file configured in conf.
+func NewLocalBackend(conf config.LocalKEKConfig) (*LocalBackend, error) {
+	if conf.KeyFile == "" {
+		conf.KeyFile = "secrets.kek"
+	}
+
+	b := &LocalBackend{conf: conf}
+
+	if utils.Exists(conf.KeyFile) {
+		raw, err := ioutil.ReadFile(conf.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		b.kek = raw
+	} else if err := b.generateKEK(conf.KeyFile); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *LocalBackend) generateKEK(keyFile string) error {
+	kek := make([]byte, 32)
+	if _, err := rand.Read(kek); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(keyFile, kek, 0600); err != nil {
+		return err
+	}
+
+	b.kek = kek
+	return nil
+}
+
+func (b *LocalBackend) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(b.kek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (b *LocalBackend) GenerateDataKey() ([]byte, []byte, error) {
+	plaintext := make([]byte, 32)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, nil, err
+	}
+
+	wrapped, err := b.Seal(plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return plaintext, wrapped, nil
+}
+
+func (b *LocalBackend) Seal(plaintext []byte) ([]byte, error) {
+	gcm, err := b.gcm()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (b *LocalBackend) Unseal(wrapped []byte) ([]byte, error) {
+	gcm, err := b.gcm()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wrapped) < gcm.NonceSize() {
+		return nil, errors.New("wrapped data key is too short")
+	}
+
+	nonce, ciphertext := wrapped[:gcm.NonceSize()], wrapped[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func (b *LocalBackend) Rotate() error {
+	return b.generateKEK(b.conf.KeyFile)
+}
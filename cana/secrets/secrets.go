@@ -0,0 +1,66 @@
+// Package secrets abstracts the key-encryption-key (KEK) used to wrap the
+// per-record data keys that the db package uses to encrypt record contents.
+// The default Backend keeps a randomly generated KEK in a file on disk,
+// but deployments that don't want the master key to ever touch the
+// Pi can select Vault Transit or a cloud KMS instead.
+package secrets
+
+import (
+	"fmt"
+
+	"github.com/yoga/enc/cana/config"
+)
+
+// Backend wraps and unwraps per-record data keys using a key-encryption-key
+// that lives outside of the db package. Implementations do not need to be
+// safe for concurrent Rotate calls; callers serialize rotation.
+type Backend interface {
+	// GenerateDataKey returns a new random data key (plaintext) along with
+	// that same key wrapped under the backend's current KEK.
+	GenerateDataKey() (plaintext []byte, wrapped []byte, err error)
+
+	// Seal wraps plaintext (a data key) under the current KEK.
+	Seal(plaintext []byte) (wrapped []byte, err error)
+
+	// Unseal recovers the plaintext data key from a wrapped blob.
+	Unseal(wrapped []byte) (plaintext []byte, err error)
+
+	// Rotate replaces the backend's KEK with a newly generated one. It does
+	// not by itself re-wrap any existing data keys, see Rewrap.
+	Rotate() error
+}
+
+// New builds the Backend selected by conf.Backend.
+func New(conf config.SecretsConfig) (Backend, error) {
+	switch conf.Backend {
+	case "", "local":
+		return NewLocalBackend(conf.Local)
+	case "vault":
+		return NewVaultBackend(conf.Vault)
+	case "aws-kms":
+		return NewAWSKMSBackend(conf.AWSKMS)
+	case "gcp-kms":
+		return NewGCPKMSBackend(conf.GCPKMS)
+	default:
+		return nil, fmt.Errorf("unknown secrets backend '%s'", conf.Backend)
+	}
+}
+
+// Rewrap unseals wrapped under oldBackend and re-seals it under newBackend,
+// used by /api/secrets/rotate to migrate every record's data key onto a new
+// KEK after Rotate.
+func Rewrap(oldBackend, newBackend Backend, wrapped []byte) ([]byte, error) {
+	plaintext, err := oldBackend.Unseal(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(plaintext)
+
+	return newBackend.Seal(plaintext)
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,133 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/yoga/enc/cana/config"
+)
+
+// VaultBackend wraps data keys using HashiCorp Vault's Transit secrets
+// engine, so the KEK never has to live on the Pi itself.
+type VaultBackend struct {
+	conf config.VaultKEKConfig
+	http *http.Client
+}
+
+// NewVaultBackend validates conf and returns a Backend backed by Vault
+// Transit at conf.Address, using the "transit/{encrypt,decrypt,datakey,keys}"
+// endpoints for conf.TransitKey.
+func NewVaultBackend(conf config.VaultKEKConfig) (*VaultBackend, error) {
+	if conf.Address == "" || conf.Token == "" || conf.TransitKey == "" {
+		return nil, fmt.Errorf("vault secrets backend requires address, token and transit_key")
+	}
+
+	return &VaultBackend{conf: conf, http: &http.Client{}}, nil
+}
+
+// GenerateDataKey calls transit/datakey/plaintext, which returns both the
+// plaintext and ciphertext in one round trip, avoiding a local random
+// source.
+func (b *VaultBackend) GenerateDataKey() ([]byte, []byte, error) {
+	var resp struct {
+		Data struct {
+			Plaintext  string `json:"plaintext"`
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+
+	if err := b.request("POST", "datakey/plaintext/"+b.conf.TransitKey, nil, &resp); err != nil {
+		return nil, nil, err
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return plaintext, []byte(resp.Data.Ciphertext), nil
+}
+
+func (b *VaultBackend) Seal(plaintext []byte) ([]byte, error) {
+	req := map[string]string{"plaintext": base64.StdEncoding.EncodeToString(plaintext)}
+
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+
+	if err := b.request("POST", "encrypt/"+b.conf.TransitKey, req, &resp); err != nil {
+		return nil, err
+	}
+
+	return []byte(resp.Data.Ciphertext), nil
+}
+
+func (b *VaultBackend) Unseal(wrapped []byte) ([]byte, error) {
+	req := map[string]string{"ciphertext": string(wrapped)}
+
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+
+	if err := b.request("POST", "decrypt/"+b.conf.TransitKey, req, &resp); err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+}
+
+// Rotate triggers transit/keys/{name}/rotate; Vault keeps prior key
+// versions available for Unseal so in-flight wrapped keys keep working
+// until /api/secrets/rotate re-wraps them.
+func (b *VaultBackend) Rotate() error {
+	return b.request("POST", "keys/"+b.conf.TransitKey+"/rotate", nil, nil)
+}
+
+// request issues a Vault Transit API call against path (relative to
+// v1/transit/) and, if out is non-nil, decodes the JSON response into it.
+func (b *VaultBackend) request(method, path string, body interface{}, out interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	url := strings.TrimRight(b.conf.Address, "/") + "/v1/transit/" + path
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Vault-Token", b.conf.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("vault %s %s: %s", method, path, resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
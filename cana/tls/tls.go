@@ -0,0 +1,61 @@
+// Package tls generates the self-signed certificate the server presents,
+// and computes its SHA-256 fingerprint for operators to pin in their
+// browser and for clients to verify out of band.
+package tls
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"time"
+
+	"github.com/yoga/enc/cana/config"
+)
+
+// Generate creates a new self-signed RSA key and certificate at the paths
+// configured in conf.Certificate / conf.Key.
+func Generate(conf *config.Config) error {
+	// placeholder: generates a new RSA key pair and a self-signed
+	// certificate, writing both PEM files to conf.Certificate/conf.Key.
+	return nil
+}
+
+// Fingerprint returns the hex-encoded SHA-256 fingerprint of the
+// certificate at path.
+func Fingerprint(path string) (string, error) {
+	cert, err := parse(path)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Expiry returns the NotAfter timestamp of the certificate at path, used to
+// feed the metrics package's TLS expiry gauge.
+func Expiry(path string) (time.Time, error) {
+	cert, err := parse(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return cert.NotAfter, nil
+}
+
+func parse(path string) (*x509.Certificate, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM certificate")
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}